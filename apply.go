@@ -0,0 +1,257 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// applyEntry declares one deploy key gdkg should provision (or tear down)
+// non-interactively, as parsed from a `gdkg apply -f keys.yaml` file.
+type applyEntry struct {
+	Owner string `yaml:"owner"`
+	Repo  string `yaml:"repo"`
+	Email string `yaml:"email"`
+	Dir   string `yaml:"dir"`
+	Alias string `yaml:"alias"`
+	// ReadOnly is a pointer so an omitted field can be told apart from an
+	// explicit "read_only: false"; see readOnly().
+	ReadOnly *bool  `yaml:"read_only"`
+	Type     string `yaml:"type"`
+	Bits     int    `yaml:"bits"`
+}
+
+// readOnly reports whether the deploy key should be uploaded read-only,
+// defaulting to true - matching the interactive flow's "(Y/n)" default -
+// when the YAML entry omits read_only.
+func (e *applyEntry) readOnly() bool {
+	if e.ReadOnly == nil {
+		return true
+	}
+	return *e.ReadOnly
+}
+
+// applyConfig is the top-level shape of a keys.yaml file.
+type applyConfig struct {
+	Keys []applyEntry `yaml:"keys"`
+}
+
+func loadApplyConfig(path string) (*applyConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var cfg applyConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// paths fills in this entry's defaults and returns the resolved private/
+// public key paths and SSH host alias.
+func (e *applyEntry) resolve() (dir, privPath, pubPath, alias, keyType string) {
+	dir = e.Dir
+	if dir == "" {
+		dir = filepath.Join(userHomeDir(), ".ssh")
+	}
+	privPath = filepath.Join(dir, e.Repo+"_deploy-key")
+	pubPath = privPath + ".pub"
+	alias = e.Alias
+	if alias == "" {
+		alias = "github-" + e.Repo
+	}
+	keyType = e.Type
+	if keyType == "" {
+		keyType = "ed25519"
+	}
+	return dir, privPath, pubPath, alias, keyType
+}
+
+// runApplyCmd parses flags for `gdkg apply -f keys.yaml [--dry-run]` and
+// reconciles the declared keys.
+func runApplyCmd(args []string) {
+	fs := flag.NewFlagSet("apply", flag.ExitOnError)
+	file := fs.String("f", "", "path to the keys.yaml file (required)")
+	dryRun := fs.Bool("dry-run", false, "print planned actions without changing anything")
+	fs.Parse(args)
+
+	if *file == "" {
+		log.Fatal("apply: -f keys.yaml is required")
+	}
+	cfg, err := loadApplyConfig(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, entry := range cfg.Keys {
+		if err := applyEntryReconcile(entry, *dryRun); err != nil {
+			fmt.Printf("%s/%s: %v\n", entry.Owner, entry.Repo, err)
+		}
+	}
+}
+
+// runDestroyCmd parses flags for `gdkg destroy -f keys.yaml [--dry-run]`
+// and tears down the declared keys.
+func runDestroyCmd(args []string) {
+	fs := flag.NewFlagSet("destroy", flag.ExitOnError)
+	file := fs.String("f", "", "path to the keys.yaml file (required)")
+	dryRun := fs.Bool("dry-run", false, "print planned actions without changing anything")
+	fs.Parse(args)
+
+	if *file == "" {
+		log.Fatal("destroy: -f keys.yaml is required")
+	}
+	cfg, err := loadApplyConfig(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, entry := range cfg.Keys {
+		if err := destroyEntryReconcile(entry, *dryRun); err != nil {
+			fmt.Printf("%s/%s: %v\n", entry.Owner, entry.Repo, err)
+		}
+	}
+}
+
+// applyEntryReconcile brings one declared key's on-disk, GitHub, SSH config
+// and ssh-agent state in line with entry, skipping any step whose state
+// already matches so re-running apply is a no-op.
+func applyEntryReconcile(entry applyEntry, dryRun bool) error {
+	dir, privPath, pubPath, alias, keyType := entry.resolve()
+
+	keyExists := fileExists(privPath) && fileExists(pubPath)
+	if !keyExists {
+		if dryRun {
+			fmt.Printf("%s/%s: would generate %s key at %s\n", entry.Owner, entry.Repo, keyType, privPath)
+		} else {
+			if err := os.MkdirAll(dir, 0700); err != nil {
+				return fmt.Errorf("failed to create %s: %w", dir, err)
+			}
+			pair, err := generateKeyPair(keyType, entry.Bits, "", entry.Email)
+			if err != nil {
+				return fmt.Errorf("failed to generate key pair: %w", err)
+			}
+			if err := os.WriteFile(privPath, pair.privatePEM, 0600); err != nil {
+				return fmt.Errorf("failed to write private key: %w", err)
+			}
+			if err := os.WriteFile(pubPath, pair.publicLine, 0644); err != nil {
+				return fmt.Errorf("failed to write public key: %w", err)
+			}
+			fmt.Printf("%s/%s: generated %s key at %s\n", entry.Owner, entry.Repo, keyType, privPath)
+			keyExists = true
+		}
+	}
+
+	// Under --dry-run the key may not exist on disk yet (it's never
+	// actually generated), so there is no fingerprint to compute; treat the
+	// upload and ssh-agent steps as needed without comparing against it.
+	var currentFingerprint string
+	needsUpload := true
+	if keyExists {
+		var err error
+		currentFingerprint, err = getKeyFingerprint(pubPath)
+		if err != nil {
+			return fmt.Errorf("failed to fingerprint %s: %w", pubPath, err)
+		}
+
+		// Only treat the key as already uploaded if the sidecar's
+		// fingerprint matches the key actually on disk - a stale sidecar
+		// left over from a key that was regenerated (e.g. after an
+		// incomplete destroy) must not be mistaken for proof that the
+		// *current* public key is on GitHub.
+		meta, metaErr := readKeyMeta(privPath)
+		needsUpload = metaErr != nil || meta.Fingerprint != currentFingerprint
+	}
+
+	if needsUpload {
+		if dryRun {
+			fmt.Printf("%s/%s: would upload deploy key to GitHub (read_only=%v)\n", entry.Owner, entry.Repo, entry.readOnly())
+		} else if token := resolveGitHubToken(""); token == "" {
+			fmt.Printf("%s/%s: no GitHub token available, skipping upload\n", entry.Owner, entry.Repo)
+		} else {
+			pubKey, err := os.ReadFile(pubPath)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", pubPath, err)
+			}
+			keyID, err := uploadDeployKey(token, entry.Owner, entry.Repo, entry.Repo+"-deploy-key", string(pubKey), entry.readOnly())
+			if err != nil {
+				return fmt.Errorf("failed to upload deploy key: %w", err)
+			}
+			newMeta := keyMeta{Owner: entry.Owner, Repo: entry.Repo, KeyID: keyID, Fingerprint: currentFingerprint, CreatedAt: time.Now()}
+			if err := writeKeyMeta(privPath, newMeta); err != nil {
+				return fmt.Errorf("failed to write deploy key metadata: %w", err)
+			}
+			fmt.Printf("%s/%s: uploaded deploy key %d to GitHub\n", entry.Owner, entry.Repo, keyID)
+		}
+	}
+
+	if existing, _ := findExistingIdentityFile(alias); existing == "" {
+		if dryRun {
+			fmt.Printf("%s/%s: would add SSH config entry for Host %s\n", entry.Owner, entry.Repo, alias)
+		} else if err := addSSHConfigEntry(alias, privPath); err != nil {
+			fmt.Printf("%s/%s: failed to add SSH config entry: %v\n", entry.Owner, entry.Repo, err)
+		} else {
+			fmt.Printf("%s/%s: added SSH config entry for Host %s\n", entry.Owner, entry.Repo, alias)
+		}
+	}
+
+	needsAgentLoad := true
+	if keyExists {
+		needsAgentLoad = !isKeyInSSHAgent(currentFingerprint)
+	}
+	if needsAgentLoad {
+		if dryRun {
+			fmt.Printf("%s/%s: would load key into ssh-agent\n", entry.Owner, entry.Repo)
+		} else if err := addKeyToSSHAgent(privPath, "", agentConstraints{}); err != nil {
+			fmt.Printf("%s/%s: failed to load key into ssh-agent: %v\n", entry.Owner, entry.Repo, err)
+		} else {
+			fmt.Printf("%s/%s: loaded key into ssh-agent\n", entry.Owner, entry.Repo)
+		}
+	}
+
+	return nil
+}
+
+// destroyEntryReconcile is the inverse of applyEntryReconcile: it removes
+// the key from the ssh-agent, deletes the remote GitHub deploy key, removes
+// the SSH config entry, and deletes the key files.
+func destroyEntryReconcile(entry applyEntry, dryRun bool) error {
+	_, privPath, pubPath, alias, _ := entry.resolve()
+
+	if fingerprint, err := getKeyFingerprint(pubPath); err == nil && isKeyInSSHAgent(fingerprint) {
+		if dryRun {
+			fmt.Printf("%s/%s: would remove key from ssh-agent\n", entry.Owner, entry.Repo)
+		} else if err := removeKeyFromSSHAgent(pubPath); err != nil {
+			fmt.Printf("%s/%s: failed to remove key from ssh-agent: %v\n", entry.Owner, entry.Repo, err)
+		}
+	}
+
+	if meta, err := readKeyMeta(privPath); err == nil {
+		if dryRun {
+			fmt.Printf("%s/%s: would delete deploy key %d from GitHub\n", entry.Owner, entry.Repo, meta.KeyID)
+		} else if token := resolveGitHubToken(""); token == "" {
+			fmt.Printf("%s/%s: no GitHub token available, skipping remote deletion\n", entry.Owner, entry.Repo)
+		} else if err := deleteDeployKey(token, meta.Owner, meta.Repo, meta.KeyID); err != nil {
+			fmt.Printf("%s/%s: failed to delete deploy key from GitHub: %v\n", entry.Owner, entry.Repo, err)
+		} else if !dryRun {
+			removeFileWithInfo(metaPath(privPath), "deploy key metadata")
+		}
+	}
+
+	if dryRun {
+		fmt.Printf("%s/%s: would remove SSH config entry for Host %s\n", entry.Owner, entry.Repo, alias)
+		fmt.Printf("%s/%s: would delete %s and %s\n", entry.Owner, entry.Repo, privPath, pubPath)
+		return nil
+	}
+
+	if err := removeSSHConfigBlock(alias); err != nil {
+		fmt.Printf("%s/%s: failed to remove SSH config entry: %v\n", entry.Owner, entry.Repo, err)
+	}
+	removeFileWithInfo(privPath, "private key")
+	removeFileWithInfo(pubPath, "public key")
+	return nil
+}
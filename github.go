@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const githubAPIBase = "https://api.github.com"
+
+// keyMeta is the sidecar JSON persisted next to a deploy key's private key
+// file (as "<repo>_deploy-key.meta.json") so that revoke and list can find
+// the matching remote key without prompting the user again.
+type keyMeta struct {
+	Owner       string    `json:"owner"`
+	Repo        string    `json:"repo"`
+	KeyID       int64     `json:"key_id"`
+	Fingerprint string    `json:"fingerprint"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// metaPath returns the sidecar path for a given private key path.
+func metaPath(privPath string) string {
+	return privPath + ".meta.json"
+}
+
+func writeKeyMeta(privPath string, meta keyMeta) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal deploy key metadata: %w", err)
+	}
+	return os.WriteFile(metaPath(privPath), data, 0600)
+}
+
+func readKeyMeta(privPath string) (keyMeta, error) {
+	var meta keyMeta
+	data, err := os.ReadFile(metaPath(privPath))
+	if err != nil {
+		return meta, err
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return meta, fmt.Errorf("failed to parse deploy key metadata: %w", err)
+	}
+	return meta, nil
+}
+
+// resolveGitHubToken returns the token to use for GitHub API calls, trying
+// (in order) the explicit flag/prompt value, the GITHUB_TOKEN environment
+// variable, and finally `gh auth token`. Returns an empty string if none is
+// available; callers should treat that as "skip GitHub API integration".
+func resolveGitHubToken(explicit string) string {
+	if explicit != "" {
+		return explicit
+	}
+	if tok := os.Getenv("GITHUB_TOKEN"); tok != "" {
+		return tok
+	}
+	out, err := exec.Command("gh", "auth", "token").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+type githubDeployKey struct {
+	ID       int64  `json:"id"`
+	Key      string `json:"key"`
+	Title    string `json:"title"`
+	ReadOnly bool   `json:"read_only"`
+}
+
+func githubRequest(token, method, url string, body interface{}) (*http.Response, error) {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, url, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return http.DefaultClient.Do(req)
+}
+
+// uploadDeployKey uploads publicKey to owner/repo as a deploy key named
+// title and returns the ID GitHub assigned to it.
+func uploadDeployKey(token, owner, repo, title, publicKey string, readOnly bool) (int64, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/keys", githubAPIBase, owner, repo)
+	resp, err := githubRequest(token, http.MethodPost, url, map[string]interface{}{
+		"title":     title,
+		"key":       publicKey,
+		"read_only": readOnly,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to upload deploy key: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return 0, fmt.Errorf("GitHub rejected deploy key upload: %s", githubErrorBody(resp))
+	}
+	var created githubDeployKey
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return 0, fmt.Errorf("failed to parse GitHub response: %w", err)
+	}
+	return created.ID, nil
+}
+
+// deleteDeployKey deletes the deploy key identified by keyID from
+// owner/repo.
+func deleteDeployKey(token, owner, repo string, keyID int64) error {
+	url := fmt.Sprintf("%s/repos/%s/%s/keys/%d", githubAPIBase, owner, repo, keyID)
+	resp, err := githubRequest(token, http.MethodDelete, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete deploy key: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("GitHub rejected deploy key deletion: %s", githubErrorBody(resp))
+	}
+	return nil
+}
+
+// listRemoteDeployKeys lists the deploy keys currently registered on
+// owner/repo.
+func listRemoteDeployKeys(token, owner, repo string) ([]githubDeployKey, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/keys", githubAPIBase, owner, repo)
+	resp, err := githubRequest(token, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deploy keys: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub rejected deploy key listing: %s", githubErrorBody(resp))
+	}
+	var keys []githubDeployKey
+	if err := json.NewDecoder(resp.Body).Decode(&keys); err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub response: %w", err)
+	}
+	return keys, nil
+}
+
+func githubErrorBody(resp *http.Response) string {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if len(data) == 0 {
+		return resp.Status
+	}
+	return fmt.Sprintf("%s: %s", resp.Status, string(data))
+}
+
+// runListCmd parses the flags for the `gdkg list` subcommand and invokes
+// listCmd.
+func runListCmd(args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	token := fs.String("token", "", "GitHub personal access token (default: $GITHUB_TOKEN or `gh auth token`)")
+	dir := fs.String("dir", filepath.Join(userHomeDir(), ".ssh"), "directory to scan for *_deploy-key.meta.json sidecars")
+	fs.Parse(args)
+
+	if err := listCmd(*dir, *token); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// listCmd implements the `gdkg list [dir]` subcommand: it reconciles every
+// "*_deploy-key.meta.json" sidecar found in dir against GitHub's view of
+// each repo's deploy keys and reports drift.
+func listCmd(dir, token string) error {
+	token = resolveGitHubToken(token)
+	if token == "" {
+		return fmt.Errorf("no GitHub token available (use --token, GITHUB_TOKEN, or `gh auth login`)")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".meta.json") {
+			continue
+		}
+		privPath := strings.TrimSuffix(filepath.Join(dir, entry.Name()), ".meta.json")
+		meta, err := readKeyMeta(privPath)
+		if err != nil {
+			fmt.Printf("Warning: failed to read %s: %v\n", entry.Name(), err)
+			continue
+		}
+
+		remoteKeys, err := listRemoteDeployKeys(token, meta.Owner, meta.Repo)
+		if err != nil {
+			fmt.Printf("%s/%s: %v\n", meta.Owner, meta.Repo, err)
+			continue
+		}
+
+		found := false
+		for _, rk := range remoteKeys {
+			if rk.ID == meta.KeyID {
+				found = true
+				break
+			}
+		}
+		if found {
+			fmt.Printf("%s/%s: key %d (%s) present\n", meta.Owner, meta.Repo, meta.KeyID, meta.Fingerprint)
+		} else {
+			fmt.Printf("%s/%s: DRIFT - key %d (%s) not found on GitHub\n", meta.Owner, meta.Repo, meta.KeyID, meta.Fingerprint)
+		}
+	}
+	return nil
+}
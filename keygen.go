@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const defaultRSABits = 3072
+
+// keyPair holds the generated key material in the on-disk formats: an
+// OpenSSH PEM-encoded private key and an authorized_keys-formatted public
+// key line (including the trailing comment).
+type keyPair struct {
+	privatePEM  []byte
+	publicLine  []byte
+	fingerprint string
+}
+
+// generateKeyPair creates a new key pair for keyType ("ed25519", "rsa" or
+// "ecdsa"). bits is only consulted for "rsa" (defaulting to defaultRSABits
+// when <= 0); ecdsa always uses the P-256 curve and ed25519 has a fixed
+// size. When passphrase is non-empty the private key is encrypted with it.
+func generateKeyPair(keyType string, bits int, passphrase, comment string) (*keyPair, error) {
+	var signer crypto.Signer
+
+	switch strings.ToLower(keyType) {
+	case "ed25519":
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate ed25519 key: %w", err)
+		}
+		signer = priv
+	case "rsa":
+		if bits <= 0 {
+			bits = defaultRSABits
+		}
+		priv, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate rsa key: %w", err)
+		}
+		signer = priv
+	case "ecdsa":
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate ecdsa key: %w", err)
+		}
+		signer = priv
+	default:
+		return nil, fmt.Errorf("unsupported key type %q (want ed25519, rsa or ecdsa)", keyType)
+	}
+
+	var (
+		privBlock *pem.Block
+		err       error
+	)
+	if passphrase != "" {
+		privBlock, err = ssh.MarshalPrivateKeyWithPassphrase(signer, comment, []byte(passphrase))
+	} else {
+		privBlock, err = ssh.MarshalPrivateKey(signer, comment)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key: %w", err)
+	}
+
+	sshPub, err := ssh.NewPublicKey(signer.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive public key: %w", err)
+	}
+
+	pubLine := strings.TrimSuffix(string(ssh.MarshalAuthorizedKey(sshPub)), "\n")
+	pubLine = fmt.Sprintf("%s %s\n", pubLine, comment)
+
+	return &keyPair{
+		privatePEM:  pem.EncodeToMemory(privBlock),
+		publicLine:  []byte(pubLine),
+		fingerprint: ssh.FingerprintSHA256(sshPub),
+	}, nil
+}
@@ -2,19 +2,34 @@ package main
 
 import (
 	"bufio"
-	"bytes"
 	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
+
+	"golang.org/x/term"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "list":
+			runListCmd(os.Args[2:])
+			return
+		case "apply":
+			runApplyCmd(os.Args[2:])
+			return
+		case "destroy":
+			runDestroyCmd(os.Args[2:])
+			return
+		}
+	}
+
 	fmt.Println("=== GitHub Deploy Key Generator ===")
 	fmt.Println("1: Generate deploy key")
 	fmt.Println("2: Remove Deploy Key")
@@ -82,22 +97,50 @@ func generateKey(scanner *bufio.Scanner) {
 		}
 	}
 
-	// Generate Ed25519 key pair using ssh-keygen
-	cmd := exec.Command("ssh-keygen", "-t", "ed25519", "-C", email, "-f", privPath, "-N", "")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		log.Fatal("Failed to generate key pair with ssh-keygen:", err)
+	// Key type and size
+	keyType, err := askInput(scanner, "Key type [ed25519|rsa|ecdsa] (default: ed25519): ")
+	if err != nil {
+		log.Fatal("Failed to read key type:", err)
+	}
+	if keyType == "" {
+		keyType = "ed25519"
+	}
+	bits := 0
+	if strings.ToLower(keyType) == "rsa" {
+		bitsInput, err := askInput(scanner, fmt.Sprintf("Key size in bits (default: %d): ", defaultRSABits))
+		if err != nil {
+			log.Fatal("Failed to read key size:", err)
+		}
+		if bitsInput != "" {
+			bits, err = strconv.Atoi(bitsInput)
+			if err != nil {
+				log.Fatal("Invalid key size:", err)
+			}
+		}
 	}
 
-	// Read and display public key for GitHub
-	pubKeyBytes, err := os.ReadFile(pubPath)
+	// Optional passphrase to encrypt the private key
+	passphrase, err := askPassword("Passphrase to encrypt the private key (optional): ")
 	if err != nil {
-		log.Fatal("Failed to read public key:", err)
+		log.Fatal("Failed to read passphrase:", err)
 	}
+
+	// Generate the key pair in-process
+	pair, err := generateKeyPair(keyType, bits, passphrase, email)
+	if err != nil {
+		log.Fatal("Failed to generate key pair:", err)
+	}
+	if err := os.WriteFile(privPath, pair.privatePEM, 0600); err != nil {
+		log.Fatal("Failed to write private key:", err)
+	}
+	if err := os.WriteFile(pubPath, pair.publicLine, 0644); err != nil {
+		log.Fatal("Failed to write public key:", err)
+	}
+
+	// Display public key for GitHub
 	fmt.Println("\n--- COPY THE PUBLIC KEY BELOW TO GITHUB ---")
-	fmt.Printf("%s", string(pubKeyBytes))
-	fmt.Println("Add this key to your GitHub repository under Settings > Deploy keys")
+	fmt.Printf("%s", string(pair.publicLine))
+	fmt.Printf("Fingerprint: %s\n", pair.fingerprint)
 
 	// Ask for GitHub username or organization
 	githubUser, err := askInput(scanner, "GitHub username or organization: ")
@@ -105,6 +148,30 @@ func generateKey(scanner *bufio.Scanner) {
 		log.Fatal("Invalid GitHub username or organization")
 	}
 
+	// Upload the deploy key via the GitHub API, if a token is available
+	token := resolveGitHubToken("")
+	if token != "" {
+		readOnlyInput, err := askInput(scanner, "Upload as read-only deploy key? (Y/n): ")
+		if err != nil {
+			log.Fatal("Failed to read read-only choice:", err)
+		}
+		readOnly := readOnlyInput == "" || strings.ToLower(readOnlyInput) == "y"
+
+		keyID, err := uploadDeployKey(token, githubUser, repo, repo+"-deploy-key", string(pair.publicLine), readOnly)
+		if err != nil {
+			fmt.Println("Failed to upload deploy key to GitHub:", err)
+		} else {
+			fmt.Printf("Deploy key uploaded to GitHub as key ID %d.\n", keyID)
+			meta := keyMeta{Owner: githubUser, Repo: repo, KeyID: keyID, Fingerprint: pair.fingerprint, CreatedAt: time.Now()}
+			if err := writeKeyMeta(privPath, meta); err != nil {
+				fmt.Println("Warning: Failed to write deploy key metadata:", err)
+			}
+		}
+	} else {
+		fmt.Println("No GitHub token available (set GITHUB_TOKEN or run `gh auth login`), skipping upload.")
+		fmt.Println("Add this key to your GitHub repository under Settings > Deploy keys")
+	}
+
 	// SSH config entry
 	createConfig, err := askInput(scanner, "\nCreate matching SSH config entry? (Y/n): ")
 	if err == nil && (createConfig == "" || strings.ToLower(createConfig) == "y") {
@@ -118,7 +185,8 @@ func generateKey(scanner *bufio.Scanner) {
 			fmt.Printf("git@%s:%s/%s.git\n", alias, githubUser, repo)
 
 			// Automatically add the private key to ssh-agent
-			if err := addKeyToSSHAgent(privPath); err != nil {
+			constraints := askAgentConstraints(scanner)
+			if err := addKeyToSSHAgent(privPath, passphrase, constraints); err != nil {
 				fmt.Printf("Warning: Failed to add key to ssh-agent: %v\n", err)
 				fmt.Println("You may need to manually run: ssh-add", privPath)
 			} else {
@@ -166,16 +234,16 @@ func revokeKey(scanner *bufio.Scanner) {
 
 	// Get the fingerprint of the key if the file exists
 	var fingerprint string
-	if fileExists(privPath) {
-		fingerprint, err = getKeyFingerprint(privPath)
+	if fileExists(pubPath) {
+		fingerprint, err = getKeyFingerprint(pubPath)
 		if err != nil {
-			fmt.Printf("Warning: Could not get fingerprint for %s: %v\n", privPath, err)
+			fmt.Printf("Warning: Could not get fingerprint for %s: %v\n", pubPath, err)
 		}
 	}
 
 	// Remove key from ssh-agent
 	if fingerprint != "" && isKeyInSSHAgent(fingerprint) {
-		if err := removeKeyFromSSHAgent(privPath); err != nil {
+		if err := removeKeyFromSSHAgent(pubPath); err != nil {
 			fmt.Printf("Warning: Failed to remove key from ssh-agent: %v\n", err)
 			fmt.Println("You may need to manually remove all keys using: ssh-add -D")
 		} else {
@@ -188,20 +256,33 @@ func revokeKey(scanner *bufio.Scanner) {
 		}
 	}
 
+	// Delete the deploy key from GitHub, if we have metadata for it. The
+	// sidecar is only removed once the remote key is actually gone, so a
+	// failed or skipped deletion can still be retried later.
+	if meta, err := readKeyMeta(privPath); err == nil {
+		token := resolveGitHubToken("")
+		if token == "" {
+			fmt.Println("No GitHub token available (set GITHUB_TOKEN or run `gh auth login`), skipping remote deletion.")
+		} else if err := deleteDeployKey(token, meta.Owner, meta.Repo, meta.KeyID); err != nil {
+			fmt.Println("Warning: Failed to delete deploy key from GitHub:", err)
+		} else {
+			fmt.Printf("Deploy key %d deleted from %s/%s on GitHub.\n", meta.KeyID, meta.Owner, meta.Repo)
+			removeFileWithInfo(metaPath(privPath), "deploy key metadata")
+		}
+	}
+
 	// Remove key files
 	removeFileWithInfo(privPath, "private key")
 	removeFileWithInfo(pubPath, "public key")
 
-	// SSH config path
-	configPath := filepath.Join(userHomeDir(), ".ssh", "config")
-	if fileExists(configPath) {
-		// Backup SSH config
-		if err := backupFile(configPath); err != nil {
-			fmt.Println("Warning: Failed to backup SSH config:", err)
+	// Remove the gdkg-managed SSH config fragment entry; the user's main
+	// ssh_config is never touched.
+	if fileExists(fragmentPath()) {
+		if err := backupFile(fragmentPath()); err != nil {
+			fmt.Println("Warning: Failed to backup SSH config fragment:", err)
 		}
-		// Remove SSH config entry
 		alias := "github-" + repo
-		if err := removeSSHConfigBlock(configPath, alias); err != nil {
+		if err := removeSSHConfigBlock(alias); err != nil {
 			fmt.Println("Warning: Failed to remove SSH config entry:", err)
 		} else {
 			fmt.Println("SSH config entry removed.")
@@ -217,6 +298,37 @@ func askInput(scanner *bufio.Scanner, prompt string) (string, error) {
 	return strings.TrimSpace(scanner.Text()), nil
 }
 
+// askPassword prompts for a secret without echoing it to the terminal.
+func askPassword(prompt string) (string, error) {
+	fmt.Print(prompt)
+	data, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// askAgentConstraints prompts for the optional ssh-agent constraints ssh-add
+// exposes via -t/-c, defaulting to "never expire" / "no confirmation" when
+// the user just presses enter.
+func askAgentConstraints(scanner *bufio.Scanner) agentConstraints {
+	var constraints agentConstraints
+	lifetime, err := askInput(scanner, "Key lifetime in ssh-agent, in seconds (blank = never expire): ")
+	if err == nil && lifetime != "" {
+		if secs, err := strconv.ParseUint(lifetime, 10, 32); err == nil {
+			constraints.LifetimeSecs = uint32(secs)
+		} else {
+			fmt.Println("Invalid lifetime, ignoring:", err)
+		}
+	}
+	confirm, err := askInput(scanner, "Require confirmation before each use of the key? (y/N): ")
+	if err == nil && strings.ToLower(confirm) == "y" {
+		constraints.ConfirmBeforeUse = true
+	}
+	return constraints
+}
+
 func removeFileWithInfo(path string, desc string) {
 	if err := os.Remove(path); err != nil {
 		if errors.Is(err, os.ErrNotExist) {
@@ -242,55 +354,6 @@ func userHomeDir() string {
 	return home
 }
 
-func addSSHConfigEntry(alias, privPath string) error {
-	configPath := filepath.Join(userHomeDir(), ".ssh", "config")
-	content, err := os.ReadFile(configPath)
-	if err != nil && !errors.Is(err, os.ErrNotExist) {
-		return err
-	}
-	if bytes.Contains(content, []byte("Host "+alias)) {
-		return fmt.Errorf("SSH config entry for Host %s already exists", alias)
-	}
-
-	entry := fmt.Sprintf("\nHost %s\n\tHostName github.com\n\tUser git\n\tIdentityFile %s\n\tIdentitiesOnly yes\n", alias, privPath)
-	f, err := os.OpenFile(configPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-	_, err = f.WriteString(entry)
-	return err
-}
-
-func removeSSHConfigBlock(configPath, alias string) error {
-	content, err := os.ReadFile(configPath)
-	if err != nil {
-		return err
-	}
-	lines := strings.Split(string(content), "\n")
-	var out []string
-	inBlock := false
-	hostLine := "Host " + alias
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, "Host ") && strings.Contains(trimmed, hostLine) {
-			inBlock = true
-			continue
-		}
-		if inBlock {
-			if strings.HasPrefix(trimmed, "Host ") && !strings.Contains(trimmed, hostLine) {
-				inBlock = false
-			} else {
-				continue
-			}
-		}
-		if !inBlock {
-			out = append(out, line)
-		}
-	}
-	return os.WriteFile(configPath, []byte(strings.Join(out, "\n")), 0600)
-}
-
 func backupFile(path string) error {
 	timestamp := time.Now().Format("20060102T150405")
 	backupPath := fmt.Sprintf("%s.backup.%s", path, timestamp)
@@ -307,79 +370,3 @@ func backupFile(path string) error {
 	_, err = io.Copy(output, input)
 	return err
 }
-
-func addKeyToSSHAgent(keyPath string) error {
-	// Check if ssh-agent is running
-	cmd := exec.Command("ssh-add", "-l")
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("ssh-agent is not running or inaccessible: %v", err)
-	}
-
-	// Add the key to ssh-agent
-	cmd = exec.Command("ssh-add", keyPath)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to add key to ssh-agent: %v", err)
-	}
-	return nil
-}
-
-func getKeyFingerprint(keyPath string) (string, error) {
-	cmd := exec.Command("ssh-keygen", "-l", "-f", keyPath)
-	output, err := cmd.Output()
-	if err != nil {
-		return "", fmt.Errorf("failed to get fingerprint: %v", err)
-	}
-
-	// Parse the fingerprint from the output, e.g.:
-	// 256 SHA256:GXfx:FizweV/CU2MsaYgH0U20kpLGZxg/2M4mXVEu3L7u+c no-email@example.com (ED25519)
-	lines := strings.Split(string(output), "\n")
-	if len(lines) == 0 || lines[0] == "" {
-		return "", fmt.Errorf("no fingerprint found in output")
-	}
-	parts := strings.Fields(lines[0])
-	if len(parts) < 2 {
-		return "", fmt.Errorf("invalid fingerprint format")
-	}
-	return parts[1], nil // Return the fingerprint (e.g., SHA256:GXfx:FizweV/...)
-}
-
-func isKeyInSSHAgent(fingerprint string) bool {
-	if fingerprint == "" {
-		return false
-	}
-
-	cmd := exec.Command("ssh-add", "-l")
-	output, err := cmd.Output()
-	if err != nil {
-		fmt.Printf("Warning: Could not list ssh-agent keys: %v\n", err)
-		return false // SSH-Agent nicht erreichbar oder leer
-	}
-
-	// Check if the fingerprint is in the output of ssh-add -l
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		if strings.Contains(line, fingerprint) {
-			return true
-		}
-	}
-	return false
-}
-
-func removeKeyFromSSHAgent(keyPath string) error {
-	// Check if ssh-agent is running
-	cmd := exec.Command("ssh-add", "-l")
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("ssh-agent is not running or inaccessible: %v", err)
-	}
-
-	// Try to remove the key from ssh-agent
-	cmd = exec.Command("ssh-add", "-d", keyPath)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to remove key from ssh-agent: %v", err)
-	}
-	return nil
-}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// agentConstraints controls how long a key added to the agent stays usable
+// and whether the agent must ask for confirmation before each use,
+// mirroring ssh-add's -t and -c flags. The zero value never expires the
+// key and never requires confirmation, matching ssh-add's own defaults.
+type agentConstraints struct {
+	LifetimeSecs     uint32
+	ConfirmBeforeUse bool
+}
+
+// withSSHAgent dials the local ssh-agent and runs fn against a client for
+// it, closing the connection afterwards.
+func withSSHAgent(fn func(agent.ExtendedAgent) error) error {
+	conn, err := dialSSHAgent()
+	if err != nil {
+		return fmt.Errorf("ssh-agent is not running or inaccessible: %w", err)
+	}
+	defer conn.Close()
+	return fn(agent.NewClient(conn))
+}
+
+// addKeyToSSHAgent parses the private key at privPath (decrypting it with
+// passphrase if non-empty) and loads it into the running ssh-agent, subject
+// to the given constraints.
+func addKeyToSSHAgent(privPath, passphrase string, constraints agentConstraints) error {
+	data, err := os.ReadFile(privPath)
+	if err != nil {
+		return fmt.Errorf("failed to read private key: %w", err)
+	}
+
+	var key interface{}
+	if passphrase != "" {
+		key, err = ssh.ParseRawPrivateKeyWithPassphrase(data, []byte(passphrase))
+	} else {
+		key, err = ssh.ParseRawPrivateKey(data)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse private key: %w", err)
+	}
+
+	return withSSHAgent(func(a agent.ExtendedAgent) error {
+		return a.Add(agent.AddedKey{
+			PrivateKey:       key,
+			Comment:          privPath,
+			LifetimeSecs:     constraints.LifetimeSecs,
+			ConfirmBeforeUse: constraints.ConfirmBeforeUse,
+		})
+	})
+}
+
+// removeKeyFromSSHAgent parses the public key at pubPath and asks the
+// ssh-agent to remove the matching key.
+func removeKeyFromSSHAgent(pubPath string) error {
+	pubKey, _, err := parseAuthorizedKeyFile(pubPath)
+	if err != nil {
+		return err
+	}
+	return withSSHAgent(func(a agent.ExtendedAgent) error {
+		return a.Remove(pubKey)
+	})
+}
+
+// isKeyInSSHAgent reports whether a key with the given SHA256 fingerprint
+// is currently loaded in the ssh-agent.
+func isKeyInSSHAgent(fingerprint string) bool {
+	if fingerprint == "" {
+		return false
+	}
+	var found bool
+	err := withSSHAgent(func(a agent.ExtendedAgent) error {
+		keys, err := a.List()
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if ssh.FingerprintSHA256(k) == fingerprint {
+				found = true
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("Warning: Could not list ssh-agent keys: %v\n", err)
+		return false
+	}
+	return found
+}
+
+// parseAuthorizedKeyFile reads and parses the single authorized_keys-format
+// public key at path.
+func parseAuthorizedKeyFile(path string) (ssh.PublicKey, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read public key: %w", err)
+	}
+	pubKey, comment, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse public key: %w", err)
+	}
+	return pubKey, comment, nil
+}
+
+// getKeyFingerprint returns the SHA256 fingerprint of the public key at
+// pubPath.
+func getKeyFingerprint(pubPath string) (string, error) {
+	pubKey, _, err := parseAuthorizedKeyFile(pubPath)
+	if err != nil {
+		return "", err
+	}
+	return ssh.FingerprintSHA256(pubKey), nil
+}
@@ -0,0 +1,19 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+)
+
+// dialSSHAgent connects to the running ssh-agent over the Unix domain
+// socket named by SSH_AUTH_SOCK.
+func dialSSHAgent() (net.Conn, error) {
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	if sock == "" {
+		return nil, fmt.Errorf("SSH_AUTH_SOCK is not set, is ssh-agent running?")
+	}
+	return net.Dial("unix", sock)
+}
@@ -0,0 +1,31 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/Microsoft/go-winio"
+)
+
+// pageantPipeName is the named pipe OpenSSH for Windows and Pageant-compatible
+// agents (e.g. Git for Windows, 1Password) listen on when SSH_AUTH_SOCK is
+// not set to a Unix-style path.
+const pageantPipeName = `\\.\pipe\openssh-ssh-agent`
+
+// dialSSHAgent connects to the running ssh-agent. On Windows SSH_AUTH_SOCK
+// is sometimes set to a named-pipe path by tools like Git for Windows; when
+// it isn't set at all, fall back to the well-known OpenSSH/Pageant pipe.
+func dialSSHAgent() (net.Conn, error) {
+	pipe := os.Getenv("SSH_AUTH_SOCK")
+	if pipe == "" {
+		pipe = pageantPipeName
+	}
+	conn, err := winio.DialPipe(pipe, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ssh-agent pipe %s: %w", pipe, err)
+	}
+	return conn, nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kevinburke/ssh_config"
+)
+
+// gdkg keeps its own SSH config entries out of the user's hand-edited main
+// config by writing them to a dedicated fragment file that the main config
+// Includes. This means removal only ever touches lines gdkg itself wrote.
+const fragmentFileName = "gdkg"
+
+func fragmentDir() string {
+	return filepath.Join(userHomeDir(), ".ssh", "config.d")
+}
+
+func fragmentPath() string {
+	return filepath.Join(fragmentDir(), fragmentFileName)
+}
+
+func mainConfigPath() string {
+	return filepath.Join(userHomeDir(), ".ssh", "config")
+}
+
+// loadSSHConfig parses an ssh_config file, returning an empty Config if the
+// file does not exist yet.
+func loadSSHConfig(path string) (*ssh_config.Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return &ssh_config.Config{}, nil
+		}
+		return nil, err
+	}
+	return ssh_config.DecodeBytes(content)
+}
+
+// findExistingIdentityFile looks through the main ssh_config for a Host
+// pattern matching alias or "github.com" that already declares an
+// IdentityFile, so callers can warn before writing gdkg's own entry.
+func findExistingIdentityFile(alias string) (string, error) {
+	cfg, err := loadSSHConfig(mainConfigPath())
+	if err != nil {
+		return "", err
+	}
+	for _, candidate := range []string{alias, "github.com"} {
+		if val, err := cfg.Get(candidate, "IdentityFile"); err == nil && val != "" {
+			return val, nil
+		}
+	}
+	return "", nil
+}
+
+// ensureIncludeDirective makes sure the main ssh_config Includes the
+// gdkg-managed fragment, prepending the directive once if missing. Include
+// directives only affect Host blocks that come after them, so it has to
+// live at the very top of the file.
+func ensureIncludeDirective() error {
+	content, err := os.ReadFile(mainConfigPath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if bytes.Contains(content, []byte(fragmentPath())) {
+		return nil
+	}
+
+	if err := os.MkdirAll(fragmentDir(), 0700); err != nil {
+		return err
+	}
+	if !fileExists(fragmentPath()) {
+		if err := os.WriteFile(fragmentPath(), nil, 0600); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(mainConfigPath()), 0700); err != nil {
+		return err
+	}
+
+	directive := fmt.Sprintf("Include %s\n\n", fragmentPath())
+	return os.WriteFile(mainConfigPath(), append([]byte(directive), content...), 0600)
+}
+
+// addSSHConfigEntry writes a Host block for alias into the gdkg-managed
+// fragment, ensuring the main ssh_config Includes it and warning if an
+// existing entry already claims an IdentityFile for this alias or for
+// github.com.
+func addSSHConfigEntry(alias, privPath string) error {
+	if existing, err := findExistingIdentityFile(alias); err == nil && existing != "" {
+		fmt.Printf("Warning: an existing SSH config entry already sets IdentityFile %s for this host\n", existing)
+	}
+
+	if err := ensureIncludeDirective(); err != nil {
+		return fmt.Errorf("failed to ensure ssh_config Include directive: %w", err)
+	}
+
+	content, err := os.ReadFile(fragmentPath())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if bytes.Contains(content, []byte("Host "+alias)) {
+		return fmt.Errorf("SSH config entry for Host %s already exists", alias)
+	}
+
+	entry := fmt.Sprintf("\nHost %s\n\tHostName github.com\n\tUser git\n\tIdentityFile %s\n\tIdentitiesOnly yes\n", alias, privPath)
+	f, err := os.OpenFile(fragmentPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(entry)
+	return err
+}
+
+// removeSSHConfigBlock removes the Host block for alias from the
+// gdkg-managed fragment file, rebuilding the file from its parsed Host list
+// rather than scanning lines - this also fixes the previous line-based
+// approach silently keeping trailing content when the removed block was the
+// last one in the file. The user's main ssh_config is never touched.
+func removeSSHConfigBlock(alias string) error {
+	if !fileExists(fragmentPath()) {
+		return nil
+	}
+	content, err := os.ReadFile(fragmentPath())
+	if err != nil {
+		return err
+	}
+	cfg, err := ssh_config.DecodeBytes(content)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", fragmentPath(), err)
+	}
+
+	hostLine := "Host " + alias
+	kept := cfg.Hosts[:0]
+	for _, host := range cfg.Hosts {
+		firstLine := strings.TrimSpace(strings.SplitN(host.String(), "\n", 2)[0])
+		if firstLine == hostLine {
+			continue
+		}
+		kept = append(kept, host)
+	}
+	cfg.Hosts = kept
+
+	return os.WriteFile(fragmentPath(), []byte(cfg.String()), 0600)
+}